@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// attachmentMarkerRe matches the Jenkins convention for embedding an
+// attachment reference inside system-out/system-err content.
+var attachmentMarkerRe = regexp.MustCompile(`\[\[ATTACHMENT\|([^\]]+)\]\]`)
+
+type AttachmentResponse struct {
+	Id string `json:"id"`
+}
+
+// resolveAttachmentPath confines an attachment path from report content
+// (system-out markers, <file> elements — both attacker-influenced when the
+// report comes from running untrusted test code) to r.attachmentsDir,
+// rejecting absolute paths and any path that escapes it via "..".
+func (r *Reporter) resolveAttachmentPath(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return "", fmt.Errorf("attachment path %q must be relative to %s", path, r.attachmentsDir)
+	}
+
+	resolved := filepath.Join(r.attachmentsDir, path)
+	rel, err := filepath.Rel(r.attachmentsDir, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("attachment path %q escapes attachments dir %s", path, r.attachmentsDir)
+	}
+	return resolved, nil
+}
+
+// uploadAttachment posts a file referenced from a JUnit report to the
+// attachments endpoint and returns its assigned ID. resolvedPath must
+// already be confined to r.attachmentsDir via resolveAttachmentPath.
+func (r *Reporter) uploadAttachment(ctx context.Context, path, resolvedPath string) (string, error) {
+	data, err := os.ReadFile(resolvedPath)
+	if err != nil {
+		return "", fmt.Errorf("read attachment %s: %w", path, err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return "", fmt.Errorf("create attachment form file: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", fmt.Errorf("write attachment data: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("close attachment form: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", r.endpoint+"/api/v1/ingress/attachments", &body)
+	if err != nil {
+		return "", fmt.Errorf("create attachment request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
+	if err := r.authenticator.Apply(httpReq); err != nil {
+		return "", fmt.Errorf("apply authentication: %w", err)
+	}
+
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("send attachment request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", &batchHTTPError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			Body:       string(bodyBytes),
+		}
+	}
+
+	var attachResp AttachmentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&attachResp); err != nil {
+		return "", fmt.Errorf("decode attachment response: %w", err)
+	}
+	return attachResp.Id, nil
+}
+
+// uploadAttachmentWithRetry retries uploadAttachment using the same
+// backoff/Retry-After policy as submitBatchWithRetry, so a flaky attachments
+// endpoint doesn't abort the whole run over one transient failure.
+func (r *Reporter) uploadAttachmentWithRetry(ctx context.Context, path string) (string, error) {
+	resolvedPath, err := r.resolveAttachmentPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	var lastErr error
+
+	for attempt := 0; attempt <= r.submitOptions.MaxRetries; attempt++ {
+		id, err := r.uploadAttachment(ctx, path, resolvedPath)
+		if err == nil {
+			return id, nil
+		}
+		lastErr = err
+
+		httpErr, ok := err.(*batchHTTPError)
+		if ok && httpErr.StatusCode >= 400 && httpErr.StatusCode < 500 && httpErr.StatusCode != http.StatusTooManyRequests {
+			return "", fmt.Errorf("upload attachment %s: %w", path, err)
+		}
+
+		if attempt == r.submitOptions.MaxRetries {
+			break
+		}
+
+		wait := backoffWithJitter(r.submitOptions.RetryBackoff, attempt)
+		if ok && httpErr.RetryAfter > 0 {
+			wait = httpErr.RetryAfter
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	return "", fmt.Errorf("upload attachment %s: exhausted retries: %w", path, lastErr)
+}
+
+// resolveAttachmentMarkers uploads every file referenced by a
+// "[[ATTACHMENT|path]]" marker in text and replaces the marker with
+// "[[ATTACHMENT|id]]", where id is the ID assigned by the ingress service.
+func (r *Reporter) resolveAttachmentMarkers(ctx context.Context, text string) (string, error) {
+	if !attachmentMarkerRe.MatchString(text) {
+		return text, nil
+	}
+
+	var uploadErr error
+	resolved := attachmentMarkerRe.ReplaceAllStringFunc(text, func(match string) string {
+		if uploadErr != nil {
+			return match
+		}
+
+		path := attachmentMarkerRe.FindStringSubmatch(match)[1]
+		id, err := r.uploadAttachmentWithRetry(ctx, path)
+		if err != nil {
+			uploadErr = err
+			return match
+		}
+		return fmt.Sprintf("[[ATTACHMENT|%s]]", id)
+	})
+	if uploadErr != nil {
+		return "", uploadErr
+	}
+	return resolved, nil
+}
+
+// resolveFileAttachments uploads every path in files (from a testcase's
+// <file> elements) and appends an attachment marker for each to text.
+func (r *Reporter) resolveFileAttachments(ctx context.Context, text string, files []string) (string, error) {
+	for _, path := range files {
+		id, err := r.uploadAttachmentWithRetry(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		if text != "" {
+			text += "\n"
+		}
+		text += fmt.Sprintf("[[ATTACHMENT|%s]]", id)
+	}
+	return text, nil
+}
+
+// resolveAttachments resolves every testcase's attachment markers and <file>
+// elements concurrently, bounded by r.submitOptions.Concurrency, instead of
+// blocking on one upload at a time. rawStdout and files are indexed in
+// parallel with testcases; the resolved text is written into
+// testcases[i].Stdout.
+func (r *Reporter) resolveAttachments(ctx context.Context, testcases []TestcaseRequest, rawStdout []string, files [][]string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sem := make(chan struct{}, max(r.submitOptions.Concurrency, 1))
+	var wg sync.WaitGroup
+	errs := make([]error, len(testcases))
+
+	for i := range testcases {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+
+			stdout, err := r.resolveAttachmentMarkers(ctx, rawStdout[i])
+			if err != nil {
+				errs[i] = err
+				cancel()
+				return
+			}
+			stdout, err = r.resolveFileAttachments(ctx, stdout, files[i])
+			if err != nil {
+				errs[i] = err
+				cancel()
+				return
+			}
+			testcases[i].Stdout = stdout
+		}(i)
+	}
+	wg.Wait()
+
+	return firstMeaningfulError(errs)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveAttachmentPathRejectsAbsolutePaths(t *testing.T) {
+	r := &Reporter{attachmentsDir: t.TempDir()}
+
+	if _, err := r.resolveAttachmentPath("/etc/passwd"); err == nil {
+		t.Fatal("expected an error for an absolute path, got nil")
+	}
+}
+
+func TestResolveAttachmentPathRejectsTraversal(t *testing.T) {
+	r := &Reporter{attachmentsDir: t.TempDir()}
+
+	for _, path := range []string{
+		"../../home/runner/.ssh/id_rsa",
+		"..",
+		"a/../../b",
+	} {
+		if _, err := r.resolveAttachmentPath(path); err == nil {
+			t.Errorf("resolveAttachmentPath(%q) expected an error, got nil", path)
+		}
+	}
+}
+
+func TestResolveAttachmentPathAllowsConfinedPaths(t *testing.T) {
+	dir := t.TempDir()
+	r := &Reporter{attachmentsDir: dir}
+
+	got, err := r.resolveAttachmentPath("logs/output.txt")
+	if err != nil {
+		t.Fatalf("resolveAttachmentPath() error: %v", err)
+	}
+	want := filepath.Join(dir, "logs", "output.txt")
+	if got != want {
+		t.Errorf("resolveAttachmentPath() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	authModeAPIKey = "apikey"
+	authModeOIDC   = "oidc"
+	authModeHMAC   = "hmac"
+)
+
+// Authenticator applies credentials to an outgoing ingress request.
+type Authenticator interface {
+	Apply(req *http.Request) error
+}
+
+// APIKeyAuthenticator sends the static x-api-key header, the reporter's
+// original (and still default) authentication mode.
+type APIKeyAuthenticator struct {
+	Key string
+}
+
+func (a APIKeyAuthenticator) Apply(req *http.Request) error {
+	req.Header.Set("x-api-key", a.Key)
+	return nil
+}
+
+// HMACAuthenticator signs each request with a shared secret so no bearer
+// token or API key needs to be transmitted at all.
+type HMACAuthenticator struct {
+	Secret string
+}
+
+func (a HMACAuthenticator) Apply(req *http.Request) error {
+	bodyHash, err := hashRequestBody(req)
+	if err != nil {
+		return fmt.Errorf("hash request body: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	canonical := strings.Join([]string{req.Method, req.URL.Path, bodyHash, timestamp}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(a.Secret))
+	mac.Write([]byte(canonical))
+
+	req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("X-Timestamp", timestamp)
+	return nil
+}
+
+func hashRequestBody(req *http.Request) (string, error) {
+	if req.GetBody == nil {
+		sum := sha256.Sum256(nil)
+		return hex.EncodeToString(sum[:]), nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// OIDCAuthenticator exchanges a CI-provided OIDC token for a short-lived
+// bearer token at a configurable endpoint, caching it in memory and
+// refreshing proactively before it expires.
+type OIDCAuthenticator struct {
+	TokenEndpoint string
+	Client        *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+type oidcTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (a *OIDCAuthenticator) Apply(req *http.Request) error {
+	token, err := a.bearerToken(req.Context())
+	if err != nil {
+		return fmt.Errorf("obtain OIDC bearer token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (a *OIDCAuthenticator) bearerToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Now().Before(a.expiry) {
+		return a.token, nil
+	}
+
+	ciToken, err := ciOIDCToken(ctx, a.client())
+	if err != nil {
+		return "", fmt.Errorf("fetch CI OIDC token: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", a.TokenEndpoint, strings.NewReader(""))
+	if err != nil {
+		return "", fmt.Errorf("create token exchange request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+ciToken)
+
+	resp, err := a.client().Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("exchange CI OIDC token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("token exchange failed: status=%d body=%s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp oidcTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("decode token exchange response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("token exchange response missing access_token")
+	}
+
+	a.token = tokenResp.AccessToken
+	ttl := time.Duration(tokenResp.ExpiresIn) * time.Second
+	a.expiry = time.Now().Add(ttl * 4 / 5) // refresh at 80% of TTL
+
+	return a.token, nil
+}
+
+func (a *OIDCAuthenticator) client() *http.Client {
+	if a.Client != nil {
+		return a.Client
+	}
+	return http.DefaultClient
+}
+
+// ciOIDCToken fetches the CI runner's own OIDC ID token, supporting GitHub
+// Actions (ACTIONS_ID_TOKEN_REQUEST_URL/TOKEN) and GitLab CI (CI_JOB_JWT_V2).
+func ciOIDCToken(ctx context.Context, client *http.Client) (string, error) {
+	if jwt := os.Getenv("CI_JOB_JWT_V2"); jwt != "" {
+		return jwt, nil
+	}
+
+	requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if requestURL == "" || requestToken == "" {
+		return "", fmt.Errorf("no CI OIDC token source found (expected CI_JOB_JWT_V2 or ACTIONS_ID_TOKEN_REQUEST_URL/TOKEN)")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("create GitHub OIDC request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request GitHub OIDC token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("GitHub OIDC token request failed: status=%d body=%s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("decode GitHub OIDC response: %w", err)
+	}
+	if tokenResp.Value == "" {
+		return "", fmt.Errorf("GitHub OIDC response missing value")
+	}
+
+	return tokenResp.Value, nil
+}
+
+// newAuthenticator builds the Authenticator selected via --auth-mode.
+func newAuthenticator(mode, apiKey, oidcTokenEndpoint, hmacSecret string) (Authenticator, error) {
+	switch mode {
+	case "", authModeAPIKey:
+		if apiKey == "" {
+			return nil, fmt.Errorf("--%s is required for auth-mode=%s", ingressAPIKeyFlag, authModeAPIKey)
+		}
+		return APIKeyAuthenticator{Key: apiKey}, nil
+	case authModeOIDC:
+		if oidcTokenEndpoint == "" {
+			return nil, fmt.Errorf("--%s is required for auth-mode=%s", oidcTokenEndpointFlag, authModeOIDC)
+		}
+		return &OIDCAuthenticator{TokenEndpoint: oidcTokenEndpoint}, nil
+	case authModeHMAC:
+		if hmacSecret == "" {
+			return nil, fmt.Errorf("--%s is required for auth-mode=%s", hmacSecretFlag, authModeHMAC)
+		}
+		return HMACAuthenticator{Secret: hmacSecret}, nil
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", mode)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACAuthenticatorSignsCanonicalString(t *testing.T) {
+	body := []byte(`{"testcases":[]}`)
+	req, err := http.NewRequest("POST", "https://ingress.example.com/api/v1/ingress/testcases", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest() error: %v", err)
+	}
+
+	auth := HMACAuthenticator{Secret: "shared-secret"}
+	if err := auth.Apply(req); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+
+	timestamp := req.Header.Get("X-Timestamp")
+	if timestamp == "" {
+		t.Fatal("Apply() did not set X-Timestamp")
+	}
+
+	bodyHash := sha256.Sum256(body)
+	canonical := strings.Join([]string{"POST", "/api/v1/ingress/testcases", hex.EncodeToString(bodyHash[:]), timestamp}, "\n")
+	mac := hmac.New(sha256.New, []byte(auth.Secret))
+	mac.Write([]byte(canonical))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	if got := req.Header.Get("X-Signature"); got != want {
+		t.Errorf("X-Signature = %q, want %q (canonical string %q)", got, want, canonical)
+	}
+}
+
+func TestHMACAuthenticatorDifferentBodiesSignDifferently(t *testing.T) {
+	auth := HMACAuthenticator{Secret: "shared-secret"}
+
+	sign := func(body string) string {
+		req, err := http.NewRequest("POST", "https://ingress.example.com/api/v1/ingress/testcases", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("NewRequest() error: %v", err)
+		}
+		if err := auth.Apply(req); err != nil {
+			t.Fatalf("Apply() error: %v", err)
+		}
+		return req.Header.Get("X-Signature")
+	}
+
+	if sign("a") == sign("b") {
+		t.Error("requests with different bodies produced the same signature")
+	}
+}
+
+func TestHashRequestBodyNilBody(t *testing.T) {
+	req, err := http.NewRequest("POST", "https://ingress.example.com/", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error: %v", err)
+	}
+
+	got, err := hashRequestBody(req)
+	if err != nil {
+		t.Fatalf("hashRequestBody() error: %v", err)
+	}
+
+	want := sha256.Sum256(nil)
+	if got != hex.EncodeToString(want[:]) {
+		t.Errorf("hashRequestBody() = %q, want sha256 of empty body", got)
+	}
+}
+
+func TestOIDCAuthenticatorCachesTokenUntilExpiry(t *testing.T) {
+	t.Setenv("CI_JOB_JWT_V2", "ci-token")
+
+	var exchanges int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		exchanges++
+		_ = json.NewEncoder(w).Encode(oidcTokenResponse{AccessToken: "bearer-token", ExpiresIn: 3600})
+	}))
+	defer server.Close()
+
+	auth := &OIDCAuthenticator{TokenEndpoint: server.URL, Client: server.Client()}
+
+	token, err := auth.bearerToken(context.Background())
+	if err != nil {
+		t.Fatalf("bearerToken() error: %v", err)
+	}
+	if token != "bearer-token" {
+		t.Errorf("token = %q, want %q", token, "bearer-token")
+	}
+
+	if _, err := auth.bearerToken(context.Background()); err != nil {
+		t.Fatalf("bearerToken() error: %v", err)
+	}
+	if exchanges != 1 {
+		t.Errorf("exchanges = %d, want 1 (second call should hit the in-memory cache)", exchanges)
+	}
+}
+
+func TestOIDCAuthenticatorRefreshesAfterExpiry(t *testing.T) {
+	t.Setenv("CI_JOB_JWT_V2", "ci-token")
+
+	var exchanges int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		exchanges++
+		_ = json.NewEncoder(w).Encode(oidcTokenResponse{AccessToken: "bearer-token", ExpiresIn: 3600})
+	}))
+	defer server.Close()
+
+	auth := &OIDCAuthenticator{TokenEndpoint: server.URL, Client: server.Client()}
+
+	if _, err := auth.bearerToken(context.Background()); err != nil {
+		t.Fatalf("bearerToken() error: %v", err)
+	}
+
+	// Simulate having passed the 80%-of-TTL refresh point.
+	auth.expiry = time.Now().Add(-time.Second)
+
+	if _, err := auth.bearerToken(context.Background()); err != nil {
+		t.Fatalf("bearerToken() error: %v", err)
+	}
+	if exchanges != 2 {
+		t.Errorf("exchanges = %d, want 2 (expired token should trigger a refresh)", exchanges)
+	}
+}
+
+func TestOIDCAuthenticatorSetsEightyPercentTTLExpiry(t *testing.T) {
+	t.Setenv("CI_JOB_JWT_V2", "ci-token")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(w).Encode(oidcTokenResponse{AccessToken: "bearer-token", ExpiresIn: 100})
+	}))
+	defer server.Close()
+
+	auth := &OIDCAuthenticator{TokenEndpoint: server.URL, Client: server.Client()}
+
+	before := time.Now()
+	if _, err := auth.bearerToken(context.Background()); err != nil {
+		t.Fatalf("bearerToken() error: %v", err)
+	}
+
+	wantMin := before.Add(79 * time.Second)
+	wantMax := before.Add(81 * time.Second)
+	if auth.expiry.Before(wantMin) || auth.expiry.After(wantMax) {
+		t.Errorf("expiry = %v, want within [%v, %v] (80%% of 100s TTL)", auth.expiry, wantMin, wantMax)
+	}
+}
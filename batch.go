@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultBatchSize    = 500
+	defaultConcurrency  = 4
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 500 * time.Millisecond
+)
+
+// SubmitOptions controls how testcases are chunked and sent to the ingress
+// endpoint.
+type SubmitOptions struct {
+	BatchSize    int
+	Concurrency  int
+	MaxRetries   int
+	RetryBackoff time.Duration // initial backoff; doubles on each subsequent attempt
+}
+
+// DefaultSubmitOptions returns the options used when the caller does not
+// override them via flags.
+func DefaultSubmitOptions() SubmitOptions {
+	return SubmitOptions{
+		BatchSize:    defaultBatchSize,
+		Concurrency:  defaultConcurrency,
+		MaxRetries:   defaultMaxRetries,
+		RetryBackoff: defaultRetryBackoff,
+	}
+}
+
+// BatchSubmitError is returned when a batch is rejected with a non-retryable
+// 4xx status, aborting the run.
+type BatchSubmitError struct {
+	BatchIndex    int
+	FirstTestcase string
+	StatusCode    int
+	Body          string
+}
+
+func (e *BatchSubmitError) Error() string {
+	return fmt.Sprintf("submit batch %d failed (first testcase %q): status=%d body=%s",
+		e.BatchIndex, e.FirstTestcase, e.StatusCode, e.Body)
+}
+
+// batchHTTPError carries the outcome of a single HTTP attempt so the retry
+// loop can decide whether to retry, honor Retry-After, or abort.
+type batchHTTPError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *batchHTTPError) Error() string {
+	return fmt.Sprintf("status=%d body=%s", e.StatusCode, e.Body)
+}
+
+func batchTestcases(testcases []TestcaseRequest, batchSize int) [][]TestcaseRequest {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	var batches [][]TestcaseRequest
+	for start := 0; start < len(testcases); start += batchSize {
+		end := min(start+batchSize, len(testcases))
+		batches = append(batches, testcases[start:end])
+	}
+	return batches
+}
+
+// joinOutput concatenates suite-level and testcase-level output (some
+// dialects, e.g. Jest and pytest, emit system-out/system-err per testcase
+// rather than per suite).
+func joinOutput(suiteOutput, testcaseOutput string) string {
+	switch {
+	case suiteOutput == "":
+		return testcaseOutput
+	case testcaseOutput == "":
+		return suiteOutput
+	default:
+		return suiteOutput + "\n" + testcaseOutput
+	}
+}
+
+func (r *Reporter) submitResults(ctx context.Context, testsuites TestSuites) error {
+	ctx, span := startSpan(ctx, "submitResults")
+	defer span.End()
+
+	var testcases []TestcaseRequest
+	var rawStdout []string
+	var files [][]string
+
+	for _, suite := range testsuites.TestSuites {
+		var suiteBaggage map[string]any
+		if len(suite.Properties) > 0 {
+			suiteBaggage = map[string]any{}
+			for _, prop := range suite.Properties {
+				suiteBaggage[prop.Name] = prop.Value
+			}
+		}
+
+		for _, tc := range suite.TestCases {
+			status := "pass"
+			var output string
+
+			if tc.Failure != nil {
+				status = "fail"
+				output = fmt.Sprintf("Failure: %s\n%s", tc.Failure.Message, tc.Failure.Content)
+			} else if tc.Error != nil {
+				status = "error"
+				output = fmt.Sprintf("Error: %s\n%s", tc.Error.Message, tc.Error.Content)
+			} else if tc.Skipped != nil {
+				status = "skip"
+				output = tc.Skipped.Message
+			}
+
+			testcases = append(testcases, TestcaseRequest{
+				SessionId:         r.sessionId,
+				TestcaseName:      tc.Name,
+				TestcaseClassname: tc.Classname,
+				Testsuite:         suite.Name,
+				Status:            status,
+				Output:            output,
+				Stderr:            joinOutput(suite.SystemErr, tc.SystemErr),
+				Baggage:           suiteBaggage,
+			})
+			rawStdout = append(rawStdout, joinOutput(suite.SystemOut, tc.SystemOut))
+			files = append(files, tc.Files)
+		}
+	}
+
+	if len(testcases) == 0 {
+		log.Println("No test results to submit")
+		return nil
+	}
+
+	if err := r.resolveAttachments(ctx, testcases, rawStdout, files); err != nil {
+		return err
+	}
+
+	batches := batchTestcases(testcases, r.submitOptions.BatchSize)
+
+	resumeFrom := 0
+	if state, err := loadSubmitState(r.idempotency.StateFile); err != nil {
+		return err
+	} else if state != nil && state.IdempotencyRoot == r.idempotency.Root {
+		if state.BatchSize != r.submitOptions.BatchSize || state.TestcaseCount != len(testcases) {
+			return fmt.Errorf("submit state %s was recorded for batch-size=%d/testcases=%d, but this run has batch-size=%d/testcases=%d: refusing to resume into mismatched batch boundaries",
+				r.idempotency.StateFile, state.BatchSize, state.TestcaseCount, r.submitOptions.BatchSize, len(testcases))
+		}
+		resumeFrom = state.LastSuccessfulBatch + 1
+		if resumeFrom > 0 {
+			log.Printf("Resuming submission from batch %d\n", resumeFrom)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sem := make(chan struct{}, max(r.submitOptions.Concurrency, 1))
+	var wg sync.WaitGroup
+	errs := make([]error, len(batches))
+	done := make([]bool, len(batches))
+	for i := range done[:resumeFrom] {
+		done[i] = true
+	}
+
+	for i := resumeFrom; i < len(batches); i++ {
+		wg.Add(1)
+		go func(i int, batch []TestcaseRequest) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+
+			if err := r.submitBatchWithRetry(ctx, i, batch); err != nil {
+				errs[i] = err
+				cancel()
+				return
+			}
+			done[i] = true
+		}(i, batches[i])
+	}
+	wg.Wait()
+
+	lastSuccessful := resumeFrom - 1
+	for i := resumeFrom; i < len(batches) && done[i]; i++ {
+		lastSuccessful = i
+	}
+	if err := saveSubmitState(r.idempotency.StateFile, submitState{
+		IdempotencyRoot:     r.idempotency.Root,
+		BatchSize:           r.submitOptions.BatchSize,
+		TestcaseCount:       len(testcases),
+		LastSuccessfulBatch: lastSuccessful,
+	}); err != nil {
+		return err
+	}
+
+	if err := firstMeaningfulError(errs); err != nil {
+		return err
+	}
+
+	log.Printf("Submitted %d test results in %d batches\n", len(testcases), len(batches))
+	return nil
+}
+
+// firstMeaningfulError picks the first non-nil error in errs, preferring any
+// error that isn't a mere consequence of another goroutine's cancellation
+// (e.g. context.Canceled) over one that is. Without this, aborting on a
+// structured *BatchSubmitError races every other in-flight batch losing to
+// ctx.Done() and reporting a generic "context canceled" at a lower index,
+// which would otherwise win by appearing first in errs.
+func firstMeaningfulError(errs []error) error {
+	var fallback error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			if fallback == nil {
+				fallback = err
+			}
+			continue
+		}
+		return err
+	}
+	return fallback
+}
+
+func (r *Reporter) submitBatchWithRetry(ctx context.Context, index int, batch []TestcaseRequest) error {
+	var lastErr error
+
+	for attempt := 0; attempt <= r.submitOptions.MaxRetries; attempt++ {
+		err := r.submitBatch(ctx, index, batch)
+		if err == nil {
+			return nil
+		}
+
+		httpErr, ok := err.(*batchHTTPError)
+		if !ok {
+			// Network-level error: always retryable.
+			lastErr = err
+		} else if httpErr.StatusCode == http.StatusTooManyRequests {
+			lastErr = err
+		} else if httpErr.StatusCode >= 400 && httpErr.StatusCode < 500 {
+			return &BatchSubmitError{
+				BatchIndex:    index,
+				FirstTestcase: batch[0].TestcaseName,
+				StatusCode:    httpErr.StatusCode,
+				Body:          httpErr.Body,
+			}
+		} else {
+			lastErr = err
+		}
+
+		if attempt == r.submitOptions.MaxRetries {
+			break
+		}
+
+		wait := backoffWithJitter(r.submitOptions.RetryBackoff, attempt)
+		if httpErr != nil && httpErr.RetryAfter > 0 {
+			wait = httpErr.RetryAfter
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return fmt.Errorf("batch %d: exhausted retries: %w", index, lastErr)
+}
+
+// maxBackoff ceilings the exponential backoff computed below. Without a
+// ceiling, doubling initial on every attempt can overflow time.Duration
+// (int64) into negative territory for large --max-retries/--retry-backoff
+// values, which makes rand.Int63n panic with a non-positive n.
+const maxBackoff = 5 * time.Minute
+
+func backoffWithJitter(initial time.Duration, attempt int) time.Duration {
+	backoff := initial
+	for i := 0; i < attempt; i++ {
+		backoff *= 2
+		if backoff <= 0 || backoff > maxBackoff {
+			backoff = maxBackoff
+			break
+		}
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(backoff) + 1))
+	return backoff/2 + jitter/2
+}
+
+func (r *Reporter) submitBatch(ctx context.Context, index int, batch []TestcaseRequest) error {
+	ctx, span := startSpan(ctx, "submitBatch", attribute.Int("batch.index", index), attribute.Int("batch.size", len(batch)))
+	defer span.End()
+
+	req := TestcasesRequest{
+		Testcases: batch,
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal testcases request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", r.endpoint+"/api/v1/ingress/testcases", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create testcases request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if err := r.authenticator.Apply(httpReq); err != nil {
+		return fmt.Errorf("apply authentication: %w", err)
+	}
+	httpReq.Header.Set("Idempotency-Key", batchIdempotencyKey(r.idempotency.Root, index))
+	injectTraceHeaders(ctx, httpReq)
+
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &batchHTTPError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			Body:       string(bodyBytes),
+		}
+	}
+
+	log.Printf("Submitted batch %d (%d test results)\n", index, len(batch))
+	return nil
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := time.Parse(time.RFC1123, value); err == nil {
+		return time.Until(when)
+	}
+	return 0
+}
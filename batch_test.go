@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSubmitResultsReturnsStructuredErrorOnConcurrentCancellation reproduces
+// a slow batch racing a fast-failing 400 batch under Concurrency: 2. The
+// slow batch's goroutine loses to ctx.Done() and would historically report
+// context.Canceled at a lower index than the real *BatchSubmitError,
+// masking the structured error submitResults is supposed to return.
+func TestSubmitResultsReturnsStructuredErrorOnConcurrentCancellation(t *testing.T) {
+	var batch0Started = make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body TestcasesRequest
+		_ = json.NewDecoder(req.Body).Decode(&body)
+
+		if len(body.Testcases) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch body.Testcases[0].TestcaseName {
+		case "slow":
+			close(batch0Started)
+			time.Sleep(200 * time.Millisecond)
+			w.WriteHeader(http.StatusCreated)
+		case "fails":
+			<-batch0Started
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"bad testcase"}`))
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	r := NewReporter(
+		server.URL,
+		"session-1",
+		"",
+		nil,
+		nil,
+		SubmitOptions{BatchSize: 1, Concurrency: 2, MaxRetries: 0, RetryBackoff: time.Millisecond},
+		IdempotencyOptions{Root: "root"},
+		APIKeyAuthenticator{},
+		t.TempDir(),
+	)
+
+	testsuites := TestSuites{
+		TestSuites: []TestSuite{
+			{
+				Name: "suite",
+				TestCases: []TestCase{
+					{Name: "slow"},
+					{Name: "fails"},
+				},
+			},
+		},
+	}
+
+	err := r.submitResults(context.Background(), testsuites)
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+
+	var batchErr *BatchSubmitError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected *BatchSubmitError, got %T: %v", err, err)
+	}
+	if batchErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("StatusCode = %d, want %d", batchErr.StatusCode, http.StatusBadRequest)
+	}
+	if batchErr.FirstTestcase != "fails" {
+		t.Errorf("FirstTestcase = %q, want %q", batchErr.FirstTestcase, "fails")
+	}
+}
+
+func TestSubmitResultsRefusesToResumeWithMismatchedBatching(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	if err := saveSubmitState(stateFile, submitState{
+		IdempotencyRoot:     "root",
+		BatchSize:           500,
+		TestcaseCount:       2,
+		LastSuccessfulBatch: 0,
+	}); err != nil {
+		t.Fatalf("saveSubmitState() error: %v", err)
+	}
+
+	r := NewReporter(
+		server.URL,
+		"session-1",
+		"",
+		nil,
+		nil,
+		SubmitOptions{BatchSize: 1, Concurrency: 1, MaxRetries: 0, RetryBackoff: time.Millisecond},
+		IdempotencyOptions{Root: "root", StateFile: stateFile},
+		APIKeyAuthenticator{},
+		t.TempDir(),
+	)
+
+	testsuites := TestSuites{
+		TestSuites: []TestSuite{
+			{Name: "suite", TestCases: []TestCase{{Name: "a"}, {Name: "b"}}},
+		},
+	}
+
+	err := r.submitResults(context.Background(), testsuites)
+	if err == nil {
+		t.Fatal("expected an error resuming with a different batch size, got nil")
+	}
+	if !strings.Contains(err.Error(), "refusing to resume") {
+		t.Errorf("error = %v, want a message about refusing to resume", err)
+	}
+}
+
+func TestFirstMeaningfulErrorPrefersNonCancellation(t *testing.T) {
+	real := &BatchSubmitError{BatchIndex: 1, StatusCode: 400}
+
+	got := firstMeaningfulError([]error{context.Canceled, real, nil})
+	if got != real {
+		t.Errorf("got %v, want %v", got, real)
+	}
+
+	got = firstMeaningfulError([]error{context.Canceled, context.DeadlineExceeded})
+	if got != context.Canceled {
+		t.Errorf("got %v, want the first cancellation error as fallback", got)
+	}
+
+	if got := firstMeaningfulError([]error{nil, nil}); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
+
+func TestBackoffWithJitterDoesNotOverflowOrPanic(t *testing.T) {
+	for _, attempt := range []int{0, 1, 35, 62, 1000} {
+		got := backoffWithJitter(500*time.Millisecond, attempt)
+		if got < 0 {
+			t.Fatalf("backoffWithJitter(500ms, %d) = %v, want non-negative", attempt, got)
+		}
+		if got > maxBackoff {
+			t.Fatalf("backoffWithJitter(500ms, %d) = %v, want <= %v", attempt, got, maxBackoff)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// IdempotencyOptions controls how the reporter deduplicates retried requests
+// across invocations.
+type IdempotencyOptions struct {
+	Root      string // root key sent with the session request and mixed into each batch key
+	StateFile string // path used to resume a partially-submitted run; empty disables persistence
+}
+
+// newIdempotencyKey generates a random UUIDv4, used as the idempotency root
+// for an invocation unless the caller supplies their own via
+// --idempotency-key.
+func newIdempotencyKey() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("generate idempotency key: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// batchIdempotencyKey derives a stable per-batch key so retries of the same
+// batch collide but distinct batches don't.
+func batchIdempotencyKey(root string, batchIndex int) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s:%d", root, batchIndex))
+	return fmt.Sprintf("%x", sum[:16])
+}
+
+// submitState is persisted to IdempotencyOptions.StateFile so a re-run after
+// a transient failure resumes from the failed batch rather than starting
+// over. BatchSize and TestcaseCount pin down the batch partitioning the
+// persisted LastSuccessfulBatch was computed against, so a re-run with a
+// different --batch-size or a different input report doesn't resume into
+// the wrong batch boundaries.
+type submitState struct {
+	IdempotencyRoot     string `json:"idempotencyRoot"`
+	BatchSize           int    `json:"batchSize"`
+	TestcaseCount       int    `json:"testcaseCount"`
+	LastSuccessfulBatch int    `json:"lastSuccessfulBatch"`
+}
+
+func loadSubmitState(path string) (*submitState, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read state file: %w", err)
+	}
+
+	var state submitState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse state file: %w", err)
+	}
+	return &state, nil
+}
+
+func saveSubmitState(path string, state submitState) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal state file: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write state file: %w", err)
+	}
+	return nil
+}
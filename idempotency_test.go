@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+var uuidV4Re = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewIdempotencyKeyFormat(t *testing.T) {
+	key, err := newIdempotencyKey()
+	if err != nil {
+		t.Fatalf("newIdempotencyKey() error: %v", err)
+	}
+	if !uuidV4Re.MatchString(key) {
+		t.Errorf("newIdempotencyKey() = %q, want a UUIDv4", key)
+	}
+}
+
+func TestNewIdempotencyKeyUnique(t *testing.T) {
+	a, err := newIdempotencyKey()
+	if err != nil {
+		t.Fatalf("newIdempotencyKey() error: %v", err)
+	}
+	b, err := newIdempotencyKey()
+	if err != nil {
+		t.Fatalf("newIdempotencyKey() error: %v", err)
+	}
+	if a == b {
+		t.Errorf("newIdempotencyKey() returned the same key twice: %q", a)
+	}
+}
+
+func TestBatchIdempotencyKeyStableAndDistinct(t *testing.T) {
+	if batchIdempotencyKey("root", 0) != batchIdempotencyKey("root", 0) {
+		t.Error("batchIdempotencyKey is not stable across calls with the same inputs")
+	}
+	if batchIdempotencyKey("root", 0) == batchIdempotencyKey("root", 1) {
+		t.Error("batchIdempotencyKey collided across batch indices")
+	}
+	if batchIdempotencyKey("root-a", 0) == batchIdempotencyKey("root-b", 0) {
+		t.Error("batchIdempotencyKey collided across roots")
+	}
+}
+
+func TestSaveAndLoadSubmitState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	want := submitState{
+		IdempotencyRoot:     "root",
+		BatchSize:           500,
+		TestcaseCount:       1234,
+		LastSuccessfulBatch: 2,
+	}
+	if err := saveSubmitState(path, want); err != nil {
+		t.Fatalf("saveSubmitState() error: %v", err)
+	}
+
+	got, err := loadSubmitState(path)
+	if err != nil {
+		t.Fatalf("loadSubmitState() error: %v", err)
+	}
+	if *got != want {
+		t.Errorf("loadSubmitState() = %+v, want %+v", *got, want)
+	}
+}
+
+func TestLoadSubmitStateMissingFile(t *testing.T) {
+	state, err := loadSubmitState(filepath.Join(t.TempDir(), "does-not-exist.json"))
+	if err != nil {
+		t.Fatalf("loadSubmitState() error: %v", err)
+	}
+	if state != nil {
+		t.Errorf("loadSubmitState() = %+v, want nil", state)
+	}
+}
+
+func TestLoadSubmitStateEmptyPathDisabled(t *testing.T) {
+	state, err := loadSubmitState("")
+	if err != nil || state != nil {
+		t.Errorf("loadSubmitState(\"\") = %+v, %v, want nil, nil", state, err)
+	}
+}
+
+func TestSaveSubmitStateEmptyPathDisabled(t *testing.T) {
+	if err := saveSubmitState("", submitState{}); err != nil {
+		t.Errorf("saveSubmitState(\"\", ...) error: %v", err)
+	}
+}
+
+func TestLoadSubmitStateCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatalf("write corrupt state file: %v", err)
+	}
+
+	if _, err := loadSubmitState(path); err == nil {
+		t.Error("loadSubmitState() expected an error for corrupt state file, got nil")
+	}
+}
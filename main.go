@@ -10,9 +10,11 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel/baggage"
 )
 
 const (
@@ -23,6 +25,19 @@ const (
 	sessionDescriptionFlag = "session-description"
 	sessionLabelsFlag      = "session-labels"
 	sessionBaggageFlag     = "session-baggage"
+	batchSizeFlag          = "batch-size"
+	concurrencyFlag        = "concurrency"
+	maxRetriesFlag         = "max-retries"
+	retryBackoffFlag       = "retry-backoff"
+	formatFlag             = "format"
+	idempotencyKeyFlag     = "idempotency-key"
+	stateFileFlag          = "state-file"
+	otlpEndpointFlag       = "otlp-endpoint"
+	otlpHeadersFlag        = "otlp-headers"
+	authModeFlag           = "auth-mode"
+	oidcTokenEndpointFlag  = "oidc-token-endpoint"
+	hmacSecretFlag         = "hmac-secret"
+	attachmentsDirFlag     = "attachments-dir"
 )
 
 type TestSuites struct {
@@ -31,14 +46,26 @@ type TestSuites struct {
 }
 
 type TestSuite struct {
-	Name      string     `xml:"name,attr"`
-	Tests     int        `xml:"tests,attr"`
-	Failures  int        `xml:"failures,attr"`
-	Errors    int        `xml:"errors,attr"`
-	Skipped   int        `xml:"skipped,attr"`
-	Time      string     `xml:"time,attr"`
-	Timestamp string     `xml:"timestamp,attr"`
-	TestCases []TestCase `xml:"testcase"`
+	Name       string     `xml:"name,attr"`
+	Tests      int        `xml:"tests,attr"`
+	Failures   int        `xml:"failures,attr"`
+	Errors     int        `xml:"errors,attr"`
+	Skipped    int        `xml:"skipped,attr"`
+	Time       string     `xml:"time,attr"`
+	Timestamp  string     `xml:"timestamp,attr"`
+	Properties []Property `xml:"properties>property"`
+	SystemOut  string     `xml:"system-out,omitempty"`
+	SystemErr  string     `xml:"system-err,omitempty"`
+	TestCases  []TestCase `xml:"testcase"`
+	// TestSuites holds nested <testsuite> children, as emitted by Maven
+	// Surefire, Jest, and pytest. The parser flattens these into dotted
+	// suite names before the reporter sees them.
+	TestSuites []TestSuite `xml:"testsuite"`
+}
+
+type Property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
 }
 
 type TestCase struct {
@@ -48,6 +75,9 @@ type TestCase struct {
 	Failure   *Failure `xml:"failure,omitempty"`
 	Error     *Error   `xml:"error,omitempty"`
 	Skipped   *Skipped `xml:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+	SystemErr string   `xml:"system-err,omitempty"`
+	Files     []string `xml:"file"`
 }
 
 type Failure struct {
@@ -90,6 +120,8 @@ type TestcaseRequest struct {
 	Testsuite         string         `json:"testsuite,omitempty"`
 	Status            string         `json:"status"`
 	Output            string         `json:"output,omitempty"`
+	Stdout            string         `json:"stdout,omitempty"`
+	Stderr            string         `json:"stderr,omitempty"`
 	Baggage           map[string]any `json:"baggage,omitempty"`
 }
 
@@ -99,26 +131,36 @@ type TestcasesRequest struct {
 
 type Reporter struct {
 	endpoint           string
-	apiKey             string
 	sessionId          string
 	sessionDescription string
 	sessionLabels      []Label
 	sessionBaggage     map[string]any
+	submitOptions      SubmitOptions
+	idempotency        IdempotencyOptions
+	authenticator      Authenticator
+	attachmentsDir     string
 	client             *http.Client
 }
 
 func NewReporter(
-	endpoint, apiKey, sessionID, sessionDescription string,
+	endpoint, sessionID, sessionDescription string,
 	sessionLabels []Label,
 	sessionBaggage map[string]any,
+	submitOptions SubmitOptions,
+	idempotency IdempotencyOptions,
+	authenticator Authenticator,
+	attachmentsDir string,
 ) *Reporter {
 	return &Reporter{
 		endpoint:           strings.TrimSuffix(endpoint, "/"),
-		apiKey:             apiKey,
 		sessionId:          sessionID,
 		sessionDescription: sessionDescription,
 		sessionLabels:      sessionLabels,
 		sessionBaggage:     sessionBaggage,
+		submitOptions:      submitOptions,
+		idempotency:        idempotency,
+		authenticator:      authenticator,
+		attachmentsDir:     attachmentsDir,
 		client:             &http.Client{},
 	}
 }
@@ -149,7 +191,10 @@ func parseLabels(labelsStr string) []Label {
 	return labels
 }
 
-func (r *Reporter) createSession() error {
+func (r *Reporter) createSession(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "createSession")
+	defer span.End()
+
 	req := SessionRequest{
 		Id:          r.sessionId,
 		Description: r.sessionDescription,
@@ -166,13 +211,17 @@ func (r *Reporter) createSession() error {
 		return fmt.Errorf("marshal session request: %w", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", r.endpoint+"/api/v1/ingress/sessions", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", r.endpoint+"/api/v1/ingress/sessions", bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("create session request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", r.apiKey)
+	if err := r.authenticator.Apply(httpReq); err != nil {
+		return fmt.Errorf("apply authentication: %w", err)
+	}
+	httpReq.Header.Set("Idempotency-Key", r.idempotency.Root)
+	injectTraceHeaders(ctx, httpReq)
 
 	resp, err := r.client.Do(httpReq)
 	if err != nil {
@@ -195,73 +244,6 @@ func (r *Reporter) createSession() error {
 	return nil
 }
 
-func (r *Reporter) submitResults(testsuites TestSuites) error {
-	var testcases []TestcaseRequest
-
-	for _, suite := range testsuites.TestSuites {
-		for _, tc := range suite.TestCases {
-			status := "pass"
-			var output string
-
-			if tc.Failure != nil {
-				status = "fail"
-				output = fmt.Sprintf("Failure: %s\n%s", tc.Failure.Message, tc.Failure.Content)
-			} else if tc.Error != nil {
-				status = "error"
-				output = fmt.Sprintf("Error: %s\n%s", tc.Error.Message, tc.Error.Content)
-			} else if tc.Skipped != nil {
-				status = "skip"
-				output = tc.Skipped.Message
-			}
-
-			testcases = append(testcases, TestcaseRequest{
-				SessionId:         r.sessionId,
-				TestcaseName:      tc.Name,
-				TestcaseClassname: tc.Classname,
-				Testsuite:         suite.Name,
-				Status:            status,
-				Output:            output,
-			})
-		}
-	}
-
-	if len(testcases) == 0 {
-		log.Println("No test results to submit")
-		return nil
-	}
-
-	req := TestcasesRequest{
-		Testcases: testcases,
-	}
-
-	body, err := json.Marshal(req)
-	if err != nil {
-		return fmt.Errorf("marshal testcases request: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", r.endpoint+"/api/v1/ingress/testcases", bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("create testcases request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", r.apiKey)
-
-	resp, err := r.client.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("send testcases request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("submit testcases failed: status=%d body=%s", resp.StatusCode, string(bodyBytes))
-	}
-
-	log.Printf("Submitted %d test results\n", len(testcases))
-	return nil
-}
-
 func run(ctx context.Context, c *cli.Command) error {
 	endpoint := c.String(ingressEndpointFlag)
 	apiKey := c.String(ingressAPIKeyFlag)
@@ -280,33 +262,94 @@ func run(ctx context.Context, c *cli.Command) error {
 		}
 	}
 
-	reporter := NewReporter(endpoint, apiKey, sessionID, sessionDescription, sessionLabels, sessionBaggage)
+	submitOptions := DefaultSubmitOptions()
+	if v := c.Int(batchSizeFlag); v > 0 {
+		submitOptions.BatchSize = v
+	}
+	if v := c.Int(concurrencyFlag); v > 0 {
+		submitOptions.Concurrency = v
+	}
+	if v := c.Int(maxRetriesFlag); v >= 0 {
+		submitOptions.MaxRetries = v
+	}
+	if v := c.Duration(retryBackoffFlag); v > 0 {
+		submitOptions.RetryBackoff = v
+	}
 
-	if err := reporter.createSession(); err != nil {
+	idempotencyRoot := c.String(idempotencyKeyFlag)
+	if idempotencyRoot == "" {
+		var err error
+		idempotencyRoot, err = newIdempotencyKey()
+		if err != nil {
+			return fmt.Errorf("generate idempotency key: %w", err)
+		}
+	}
+	idempotency := IdempotencyOptions{
+		Root:      idempotencyRoot,
+		StateFile: c.String(stateFileFlag),
+	}
+
+	tracingOptions := TracingOptions{
+		OTLPEndpoint: c.String(otlpEndpointFlag),
+		OTLPHeaders:  parseOTLPHeaders(c.String(otlpHeadersFlag)),
+	}
+	shutdownTracing, err := setupTracing(ctx, tracingOptions)
+	if err != nil {
+		return fmt.Errorf("setup tracing: %w", err)
+	}
+	defer shutdownTracing(ctx)
+
+	ctx = extractEnvTraceContext(ctx, os.Getenv)
+	if baggageSet, err := parseBaggageJSON(sessionBaggage); err != nil {
+		return fmt.Errorf("convert session baggage to W3C baggage: %w", err)
+	} else if baggageSet.Len() > 0 {
+		ctx = baggage.ContextWithBaggage(ctx, baggageSet)
+	}
+
+	ctx, rootSpan := startSpan(ctx, "greener.report")
+	defer rootSpan.End()
+
+	authenticator, err := newAuthenticator(c.String(authModeFlag), apiKey, c.String(oidcTokenEndpointFlag), c.String(hmacSecretFlag))
+	if err != nil {
+		return fmt.Errorf("configure authentication: %w", err)
+	}
+
+	attachmentsDir, err := filepath.Abs(c.String(attachmentsDirFlag))
+	if err != nil {
+		return fmt.Errorf("resolve attachments dir: %w", err)
+	}
+
+	reporter := NewReporter(endpoint, sessionID, sessionDescription, sessionLabels, sessionBaggage, submitOptions, idempotency, authenticator, attachmentsDir)
+
+	if err := reporter.createSession(ctx); err != nil {
 		return fmt.Errorf("create session: %w", err)
 	}
 
-	var xmlData []byte
-	var err error
+	var reportData []byte
 
 	if xmlFile == "-" {
-		xmlData, err = io.ReadAll(os.Stdin)
+		reportData, err = io.ReadAll(os.Stdin)
 		if err != nil {
 			return fmt.Errorf("read from stdin: %w", err)
 		}
 	} else {
-		xmlData, err = os.ReadFile(xmlFile)
+		reportData, err = os.ReadFile(xmlFile)
 		if err != nil {
 			return fmt.Errorf("read file %s: %w", xmlFile, err)
 		}
 	}
 
-	var testsuites TestSuites
-	if err := xml.Unmarshal(xmlData, &testsuites); err != nil {
-		return fmt.Errorf("parse XML: %w", err)
+	parser, err := parserFor(c.String(formatFlag), reportData)
+	if err != nil {
+		return fmt.Errorf("select parser: %w", err)
+	}
+
+	testsuites, err := parser.Parse(bytes.NewReader(reportData))
+	if err != nil {
+		return fmt.Errorf("parse report: %w", err)
 	}
 
-	if err := reporter.submitResults(testsuites); err != nil {
+	if err := reporter.submitResults(ctx, testsuites); err != nil {
 		return fmt.Errorf("submit results: %w", err)
 	}
 
@@ -325,10 +368,9 @@ func main() {
 				Required: true,
 			},
 			&cli.StringFlag{
-				Name:     ingressAPIKeyFlag,
-				Usage:    "Greener ingress API key",
-				Sources:  cli.EnvVars("GREENER_INGRESS_API_KEY"),
-				Required: true,
+				Name:    ingressAPIKeyFlag,
+				Usage:   "Greener ingress API key (required for --auth-mode=apikey)",
+				Sources: cli.EnvVars("GREENER_INGRESS_API_KEY"),
 			},
 			&cli.StringFlag{
 				Name:     xmlFileFlag,
@@ -356,6 +398,78 @@ func main() {
 				Usage:   "Session baggage (JSON object)",
 				Sources: cli.EnvVars("GREENER_SESSION_BAGGAGE"),
 			},
+			&cli.IntFlag{
+				Name:    batchSizeFlag,
+				Usage:   "Number of testcases per submission batch",
+				Value:   defaultBatchSize,
+				Sources: cli.EnvVars("GREENER_BATCH_SIZE"),
+			},
+			&cli.IntFlag{
+				Name:    concurrencyFlag,
+				Usage:   "Maximum number of batches submitted concurrently",
+				Value:   defaultConcurrency,
+				Sources: cli.EnvVars("GREENER_CONCURRENCY"),
+			},
+			&cli.IntFlag{
+				Name:    maxRetriesFlag,
+				Usage:   "Maximum number of retries per batch",
+				Value:   defaultMaxRetries,
+				Sources: cli.EnvVars("GREENER_MAX_RETRIES"),
+			},
+			&cli.DurationFlag{
+				Name:    retryBackoffFlag,
+				Usage:   "Initial backoff between batch retries",
+				Value:   defaultRetryBackoff,
+				Sources: cli.EnvVars("GREENER_RETRY_BACKOFF"),
+			},
+			&cli.StringFlag{
+				Name:    formatFlag,
+				Usage:   "Report format: auto, junit, gotest, tap, or xunit2",
+				Value:   formatAuto,
+				Sources: cli.EnvVars("GREENER_FORMAT"),
+			},
+			&cli.StringFlag{
+				Name:    idempotencyKeyFlag,
+				Usage:   "Idempotency root key (generated if not provided)",
+				Sources: cli.EnvVars("GREENER_IDEMPOTENCY_KEY"),
+			},
+			&cli.StringFlag{
+				Name:    stateFileFlag,
+				Usage:   "Path to a state file used to resume a partially-submitted run",
+				Sources: cli.EnvVars("GREENER_STATE_FILE"),
+			},
+			&cli.StringFlag{
+				Name:    otlpEndpointFlag,
+				Usage:   "OTLP/HTTP endpoint to export spans to (tracing is a no-op if unset)",
+				Sources: cli.EnvVars("GREENER_OTLP_ENDPOINT"),
+			},
+			&cli.StringFlag{
+				Name:    otlpHeadersFlag,
+				Usage:   "Comma-separated headers for the OTLP exporter (e.g. 'key=value,key2=value2')",
+				Sources: cli.EnvVars("GREENER_OTLP_HEADERS"),
+			},
+			&cli.StringFlag{
+				Name:    authModeFlag,
+				Usage:   "Authentication mode: apikey, oidc, or hmac",
+				Value:   authModeAPIKey,
+				Sources: cli.EnvVars("GREENER_AUTH_MODE"),
+			},
+			&cli.StringFlag{
+				Name:    oidcTokenEndpointFlag,
+				Usage:   "Token endpoint to exchange the CI OIDC token for a bearer token (--auth-mode=oidc)",
+				Sources: cli.EnvVars("GREENER_OIDC_TOKEN_ENDPOINT"),
+			},
+			&cli.StringFlag{
+				Name:    hmacSecretFlag,
+				Usage:   "Shared secret used to sign requests (--auth-mode=hmac)",
+				Sources: cli.EnvVars("GREENER_HMAC_SECRET"),
+			},
+			&cli.StringFlag{
+				Name:    attachmentsDirFlag,
+				Usage:   "Base directory attachment paths (from [[ATTACHMENT|path]] markers and <file> elements) are confined to",
+				Value:   ".",
+				Sources: cli.EnvVars("GREENER_ATTACHMENTS_DIR"),
+			},
 		},
 		Action: run,
 	}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+const (
+	formatAuto   = "auto"
+	formatJUnit  = "junit"
+	formatGoTest = "gotest"
+	formatTAP    = "tap"
+	formatXUnit2 = "xunit2"
+)
+
+// Parser converts a test report in some format into the uniform TestSuites
+// representation consumed by submitResults.
+type Parser interface {
+	Parse(r io.Reader) (TestSuites, error)
+}
+
+// parserFor resolves the --format flag value to a Parser, sniffing the
+// report content when format is "auto" or empty.
+func parserFor(format string, data []byte) (Parser, error) {
+	if format == "" || format == formatAuto {
+		format = sniffFormat(data)
+	}
+
+	switch format {
+	case formatJUnit:
+		return JUnitParser{}, nil
+	case formatGoTest:
+		return GoTestParser{}, nil
+	case formatTAP:
+		return TAPParser{}, nil
+	case formatXUnit2:
+		return XUnit2Parser{}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+var tapPlanRe = regexp.MustCompile(`^\d+\.\.\d+`)
+
+// sniffFormat guesses the report format from its first non-whitespace byte:
+// '{' -> go test -json, 'T'/a TAP plan line -> TAP, '<' -> XML (dispatched by
+// root element), anything else falls back to JUnit.
+func sniffFormat(data []byte) string {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) == 0 {
+		return formatJUnit
+	}
+
+	switch trimmed[0] {
+	case '{':
+		return formatGoTest
+	case '<':
+		return sniffXMLFormat(trimmed)
+	default:
+		if bytes.HasPrefix(trimmed, []byte("TAP")) || tapPlanRe.Match(trimmed) {
+			return formatTAP
+		}
+		return formatJUnit
+	}
+}
+
+// sniffXMLFormat distinguishes JUnit XML from the xUnit.net v2 dialect by
+// looking at the document's root element.
+func sniffXMLFormat(data []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return formatJUnit
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			if start.Name.Local == "assemblies" {
+				return formatXUnit2
+			}
+			return formatJUnit
+		}
+	}
+}
+
+// JUnitParser parses standard JUnit XML (<testsuites><testsuite><testcase>).
+type JUnitParser struct{}
+
+func (JUnitParser) Parse(r io.Reader) (TestSuites, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return TestSuites{}, fmt.Errorf("read report: %w", err)
+	}
+
+	var testsuites TestSuites
+	if err := xml.Unmarshal(data, &testsuites); err != nil {
+		return TestSuites{}, fmt.Errorf("parse JUnit XML: %w", err)
+	}
+
+	testsuites.TestSuites = flattenSuites(testsuites.TestSuites, "")
+	return testsuites, nil
+}
+
+// flattenSuites recursively folds nested <testsuite> children (Maven
+// Surefire, Jest, pytest) into a single flat slice, joining ancestor names
+// with "." so the wire format stays flat.
+func flattenSuites(suites []TestSuite, prefix string) []TestSuite {
+	var flat []TestSuite
+	for _, suite := range suites {
+		name := suite.Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		flattened := suite
+		flattened.Name = name
+		flattened.TestSuites = nil
+		flat = append(flat, flattened)
+
+		flat = append(flat, flattenSuites(suite.TestSuites, name)...)
+	}
+	return flat
+}
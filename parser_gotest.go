@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// goTestEvent mirrors one line of `go test -json` output, as documented by
+// the test2json tool.
+type goTestEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+type goTestCase struct {
+	status  string
+	elapsed float64
+	output  strings.Builder
+}
+
+// GoTestParser aggregates `go test -json` events into synthetic suites keyed
+// by package.
+type GoTestParser struct{}
+
+func (GoTestParser) Parse(r io.Reader) (TestSuites, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	packages := map[string]map[string]*goTestCase{}
+	var packageOrder []string
+	testOrder := map[string][]string{}
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var ev goTestEvent
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return TestSuites{}, fmt.Errorf("parse go test json event: %w", err)
+		}
+
+		if ev.Test == "" {
+			// Package-level event (build, start, pass/fail for the package
+			// as a whole); nothing to attach to an individual testcase.
+			continue
+		}
+
+		tests, ok := packages[ev.Package]
+		if !ok {
+			tests = map[string]*goTestCase{}
+			packages[ev.Package] = tests
+			packageOrder = append(packageOrder, ev.Package)
+		}
+
+		tc, ok := tests[ev.Test]
+		if !ok {
+			tc = &goTestCase{}
+			tests[ev.Test] = tc
+			testOrder[ev.Package] = append(testOrder[ev.Package], ev.Test)
+		}
+
+		switch ev.Action {
+		case "output":
+			tc.output.WriteString(ev.Output)
+		case "pass", "fail", "skip":
+			tc.status = ev.Action
+			tc.elapsed = ev.Elapsed
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return TestSuites{}, fmt.Errorf("read go test json stream: %w", err)
+	}
+
+	var testsuites TestSuites
+	for _, pkg := range packageOrder {
+		suite := TestSuite{Name: pkg}
+
+		for _, name := range testOrder[pkg] {
+			tc := packages[pkg][name]
+
+			testcase := TestCase{
+				Name: name,
+				Time: strconv.FormatFloat(tc.elapsed, 'f', -1, 64),
+			}
+
+			switch tc.status {
+			case "fail":
+				testcase.Failure = &Failure{Content: tc.output.String()}
+				suite.Failures++
+			case "skip":
+				testcase.Skipped = &Skipped{Message: tc.output.String()}
+				suite.Skipped++
+			}
+
+			suite.TestCases = append(suite.TestCases, testcase)
+			suite.Tests++
+		}
+
+		testsuites.TestSuites = append(testsuites.TestSuites, suite)
+	}
+
+	return testsuites, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoTestParserParse(t *testing.T) {
+	input := strings.Join([]string{
+		`{"Action":"run","Package":"pkg","Test":"TestA"}`,
+		`{"Action":"output","Package":"pkg","Test":"TestA","Output":"hello\n"}`,
+		`{"Action":"pass","Package":"pkg","Test":"TestA","Elapsed":0.01}`,
+		`{"Action":"run","Package":"pkg","Test":"TestB"}`,
+		`{"Action":"output","Package":"pkg","Test":"TestB","Output":"boom\n"}`,
+		`{"Action":"fail","Package":"pkg","Test":"TestB","Elapsed":0.02}`,
+		`{"Action":"run","Package":"pkg","Test":"TestC"}`,
+		`{"Action":"skip","Package":"pkg","Test":"TestC","Elapsed":0}`,
+		`{"Action":"pass","Package":"pkg"}`,
+	}, "\n")
+
+	suites, err := GoTestParser{}.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if len(suites.TestSuites) != 1 {
+		t.Fatalf("got %d suites, want 1", len(suites.TestSuites))
+	}
+	suite := suites.TestSuites[0]
+	if suite.Name != "pkg" {
+		t.Errorf("suite.Name = %q, want %q", suite.Name, "pkg")
+	}
+	if len(suite.TestCases) != 3 {
+		t.Fatalf("got %d testcases, want 3", len(suite.TestCases))
+	}
+
+	a, b, c := suite.TestCases[0], suite.TestCases[1], suite.TestCases[2]
+	if a.Name != "TestA" || a.Failure != nil || a.Skipped != nil {
+		t.Errorf("TestA = %+v, want a passing testcase", a)
+	}
+	if b.Name != "TestB" || b.Failure == nil || b.Failure.Content != "boom\n" {
+		t.Errorf("TestB = %+v, want a failure with content %q", b, "boom\n")
+	}
+	if c.Name != "TestC" || c.Skipped == nil {
+		t.Errorf("TestC = %+v, want a skipped testcase", c)
+	}
+
+	if suite.Tests != 3 || suite.Failures != 1 || suite.Skipped != 1 {
+		t.Errorf("suite counts = tests=%d failures=%d skipped=%d, want 3/1/1", suite.Tests, suite.Failures, suite.Skipped)
+	}
+}
+
+func TestGoTestParserInvalidJSON(t *testing.T) {
+	if _, err := (GoTestParser{}).Parse(strings.NewReader("not json")); err == nil {
+		t.Error("Parse() expected an error for invalid JSON, got nil")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var tapResultRe = regexp.MustCompile(`^(ok|not ok)\s*(\d+)?\s*-?\s*(.*)$`)
+
+// TAPParser parses a TAP v13 stream into a single synthetic suite. Per-line
+// directives (`# SKIP ...`, `# TODO ...`) and YAML diagnostic blocks
+// (`---` ... `...`) are folded into the corresponding testcase.
+type TAPParser struct{}
+
+func (TAPParser) Parse(r io.Reader) (TestSuites, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	suite := TestSuite{Name: "TAP"}
+	var current *TestCase
+	var diagnostic strings.Builder
+	inYAML := false
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if diagnostic.Len() > 0 {
+			switch {
+			case current.Failure != nil:
+				current.Failure.Content = diagnostic.String()
+			case current.Error != nil:
+				current.Error.Content = diagnostic.String()
+			}
+		}
+		suite.TestCases = append(suite.TestCases, *current)
+		suite.Tests++
+		current = nil
+		diagnostic.Reset()
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if inYAML {
+			if trimmed == "..." {
+				inYAML = false
+			} else {
+				diagnostic.WriteString(line)
+				diagnostic.WriteString("\n")
+			}
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			continue
+		case trimmed == "---":
+			inYAML = true
+			continue
+		case strings.HasPrefix(trimmed, "#"), strings.HasPrefix(trimmed, "TAP version"), tapPlanRe.MatchString(trimmed):
+			continue
+		}
+
+		m := tapResultRe.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		flush()
+
+		ok := m[1] == "ok"
+		desc := m[3]
+		directive := ""
+		if idx := strings.Index(desc, "#"); idx >= 0 {
+			directive = strings.TrimSpace(desc[idx+1:])
+			desc = strings.TrimSpace(desc[:idx])
+		}
+
+		tc := TestCase{Name: desc}
+		switch {
+		case strings.HasPrefix(strings.ToUpper(directive), "SKIP"):
+			tc.Skipped = &Skipped{Message: directive}
+			suite.Skipped++
+		case strings.HasPrefix(strings.ToUpper(directive), "TODO"):
+			// A TODO test is expected to fail; per TAP semantics it's not a
+			// hard failure regardless of ok/not ok, so it's folded in next
+			// to SKIP rather than counted against suite.Failures.
+			tc.Skipped = &Skipped{Message: directive}
+			suite.Skipped++
+		case !ok:
+			tc.Failure = &Failure{Message: directive}
+			suite.Failures++
+		}
+		current = &tc
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return TestSuites{}, fmt.Errorf("read TAP stream: %w", err)
+	}
+
+	return TestSuites{TestSuites: []TestSuite{suite}}, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTAPParserParse(t *testing.T) {
+	input := `TAP version 13
+1..4
+ok 1 - addition works
+not ok 2 - subtraction works
+  ---
+  message: "expected 2, got 3"
+  ...
+ok 3 - rounding # SKIP not implemented on this platform
+not ok 4 - flaky network call # TODO fix retries
+`
+
+	suites, err := TAPParser{}.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if len(suites.TestSuites) != 1 {
+		t.Fatalf("got %d suites, want 1", len(suites.TestSuites))
+	}
+	suite := suites.TestSuites[0]
+	if len(suite.TestCases) != 4 {
+		t.Fatalf("got %d testcases, want 4", len(suite.TestCases))
+	}
+
+	addition, subtraction, rounding, flaky := suite.TestCases[0], suite.TestCases[1], suite.TestCases[2], suite.TestCases[3]
+
+	if addition.Failure != nil || addition.Skipped != nil {
+		t.Errorf("addition = %+v, want a passing testcase", addition)
+	}
+
+	if subtraction.Failure == nil {
+		t.Fatalf("subtraction.Failure = nil, want a failure")
+	}
+	if !strings.Contains(subtraction.Failure.Content, `expected 2, got 3`) {
+		t.Errorf("subtraction.Failure.Content = %q, want it to contain the YAML diagnostic", subtraction.Failure.Content)
+	}
+
+	if rounding.Skipped == nil {
+		t.Fatalf("rounding.Skipped = nil, want a SKIP directive folded into Skipped")
+	}
+
+	// The regression this covers: a "not ok ... # TODO ..." line must be
+	// folded into Skipped, not counted as a hard Failure.
+	if flaky.Failure != nil {
+		t.Errorf("flaky.Failure = %+v, want nil (TODO directives are not hard failures)", flaky.Failure)
+	}
+	if flaky.Skipped == nil {
+		t.Fatalf("flaky.Skipped = nil, want the TODO directive folded into Skipped")
+	}
+
+	if suite.Tests != 4 {
+		t.Errorf("suite.Tests = %d, want 4", suite.Tests)
+	}
+	if suite.Failures != 1 {
+		t.Errorf("suite.Failures = %d, want 1 (only the real failure, not the TODO)", suite.Failures)
+	}
+	if suite.Skipped != 2 {
+		t.Errorf("suite.Skipped = %d, want 2 (one SKIP, one TODO)", suite.Skipped)
+	}
+}
+
+func TestTAPParserOkWithoutDirectiveIsNotCountedAsSkipped(t *testing.T) {
+	input := "1..1\nok 1 - plain pass\n"
+
+	suites, err := TAPParser{}.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	suite := suites.TestSuites[0]
+	if suite.Skipped != 0 || suite.Failures != 0 {
+		t.Errorf("suite counts = skipped=%d failures=%d, want 0/0", suite.Skipped, suite.Failures)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import "testing"
+
+func TestSniffFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"junit xml", `<testsuites><testsuite name="s"></testsuite></testsuites>`, formatJUnit},
+		{"xunit2 xml", `<assemblies><assembly></assembly></assemblies>`, formatXUnit2},
+		{"gotest json", `{"Action":"run","Test":"TestFoo"}`, formatGoTest},
+		{"tap version", "TAP version 13\nok 1 - test\n", formatTAP},
+		{"tap plan line", "1..3\nok 1 - test\n", formatTAP},
+		{"leading whitespace", "  \n\t<testsuites></testsuites>", formatJUnit},
+		{"empty", "", formatJUnit},
+		{"unrecognized falls back to junit", "not xml or json or tap", formatJUnit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sniffFormat([]byte(tt.data))
+			if got != tt.want {
+				t.Errorf("sniffFormat(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParserFor(t *testing.T) {
+	tests := []struct {
+		format  string
+		data    string
+		wantErr bool
+	}{
+		{formatJUnit, "", false},
+		{formatGoTest, "", false},
+		{formatTAP, "", false},
+		{formatXUnit2, "", false},
+		{formatAuto, `<testsuites></testsuites>`, false},
+		{"", `<testsuites></testsuites>`, false},
+		{"bogus", "", true},
+	}
+
+	for _, tt := range tests {
+		p, err := parserFor(tt.format, []byte(tt.data))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parserFor(%q, ...) expected an error, got nil", tt.format)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parserFor(%q, ...) unexpected error: %v", tt.format, err)
+		}
+		if p == nil {
+			t.Errorf("parserFor(%q, ...) returned nil parser", tt.format)
+		}
+	}
+}
+
+func TestFlattenSuites(t *testing.T) {
+	nested := []TestSuite{
+		{
+			Name: "outer",
+			TestSuites: []TestSuite{
+				{Name: "inner"},
+			},
+		},
+	}
+
+	flat := flattenSuites(nested, "")
+	if len(flat) != 2 {
+		t.Fatalf("got %d suites, want 2", len(flat))
+	}
+	if flat[0].Name != "outer" {
+		t.Errorf("flat[0].Name = %q, want %q", flat[0].Name, "outer")
+	}
+	if flat[1].Name != "outer.inner" {
+		t.Errorf("flat[1].Name = %q, want %q", flat[1].Name, "outer.inner")
+	}
+	if flat[0].TestSuites != nil {
+		t.Errorf("flat[0].TestSuites = %v, want nil", flat[0].TestSuites)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xUnit.net v2 XML nests assemblies/collections/tests rather than JUnit's
+// flat testsuites/testsuite/testcase.
+type xunit2Assemblies struct {
+	XMLName    xml.Name         `xml:"assemblies"`
+	Assemblies []xunit2Assembly `xml:"assembly"`
+}
+
+type xunit2Assembly struct {
+	Name        string             `xml:"name,attr"`
+	Collections []xunit2Collection `xml:"collection"`
+}
+
+type xunit2Collection struct {
+	Name  string       `xml:"name,attr"`
+	Tests []xunit2Test `xml:"test"`
+}
+
+type xunit2Test struct {
+	Name    string         `xml:"name,attr"`
+	Type    string         `xml:"type,attr"`
+	Method  string         `xml:"method,attr"`
+	Time    string         `xml:"time,attr"`
+	Result  string         `xml:"result,attr"`
+	Reason  string         `xml:"reason"`
+	Failure *xunit2Failure `xml:"failure,omitempty"`
+}
+
+type xunit2Failure struct {
+	Message    string `xml:"message"`
+	StackTrace string `xml:"stack-trace"`
+}
+
+// XUnit2Parser parses the xUnit.net v2 XML dialect
+// (<assemblies><assembly><collection><test>).
+type XUnit2Parser struct{}
+
+func (XUnit2Parser) Parse(r io.Reader) (TestSuites, error) {
+	var doc xunit2Assemblies
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return TestSuites{}, fmt.Errorf("parse xUnit.net v2 XML: %w", err)
+	}
+
+	var testsuites TestSuites
+	for _, assembly := range doc.Assemblies {
+		for _, collection := range assembly.Collections {
+			suite := TestSuite{Name: assembly.Name + "/" + collection.Name}
+
+			for _, test := range collection.Tests {
+				name := test.Method
+				if name == "" {
+					name = test.Name
+				}
+
+				testcase := TestCase{
+					Name:      name,
+					Classname: test.Type,
+					Time:      test.Time,
+				}
+
+				switch strings.ToLower(test.Result) {
+				case "fail":
+					testcase.Failure = &Failure{}
+					if test.Failure != nil {
+						testcase.Failure.Message = test.Failure.Message
+						testcase.Failure.Content = test.Failure.StackTrace
+					}
+					suite.Failures++
+				case "skip":
+					testcase.Skipped = &Skipped{Message: test.Reason}
+					suite.Skipped++
+				}
+
+				suite.TestCases = append(suite.TestCases, testcase)
+				suite.Tests++
+			}
+
+			testsuites.TestSuites = append(testsuites.TestSuites, suite)
+		}
+	}
+
+	return testsuites, nil
+}
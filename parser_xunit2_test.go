@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestXUnit2ParserParse(t *testing.T) {
+	input := `<assemblies>
+	<assembly name="MyAssembly.dll">
+		<collection name="MyCollection">
+			<test name="MyNamespace.MyTests.PassingTest" type="MyNamespace.MyTests" method="PassingTest" time="0.01" result="Pass" />
+			<test name="MyNamespace.MyTests.FailingTest" type="MyNamespace.MyTests" method="FailingTest" time="0.02" result="Fail">
+				<failure>
+					<message>expected true, got false</message>
+					<stack-trace>at MyTests.FailingTest()</stack-trace>
+				</failure>
+			</test>
+			<test name="MyNamespace.MyTests.SkippedTest" type="MyNamespace.MyTests" method="SkippedTest" time="0" result="Skip">
+				<reason>not implemented yet</reason>
+			</test>
+		</collection>
+	</assembly>
+</assemblies>`
+
+	suites, err := XUnit2Parser{}.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if len(suites.TestSuites) != 1 {
+		t.Fatalf("got %d suites, want 1", len(suites.TestSuites))
+	}
+	suite := suites.TestSuites[0]
+	if suite.Name != "MyAssembly.dll/MyCollection" {
+		t.Errorf("suite.Name = %q, want %q", suite.Name, "MyAssembly.dll/MyCollection")
+	}
+	if len(suite.TestCases) != 3 {
+		t.Fatalf("got %d testcases, want 3", len(suite.TestCases))
+	}
+
+	pass, fail, skip := suite.TestCases[0], suite.TestCases[1], suite.TestCases[2]
+	if pass.Name != "PassingTest" || pass.Failure != nil || pass.Skipped != nil {
+		t.Errorf("PassingTest = %+v, want a passing testcase", pass)
+	}
+	if fail.Name != "FailingTest" || fail.Failure == nil || fail.Failure.Message != "expected true, got false" {
+		t.Errorf("FailingTest = %+v, want a failure with the expected message", fail)
+	}
+	if fail.Failure != nil && fail.Failure.Content != "at MyTests.FailingTest()" {
+		t.Errorf("FailingTest.Failure.Content = %q, want stack trace content", fail.Failure.Content)
+	}
+	if skip.Name != "SkippedTest" || skip.Skipped == nil || skip.Skipped.Message != "not implemented yet" {
+		t.Errorf("SkippedTest = %+v, want a skipped testcase with the reason", skip)
+	}
+
+	if suite.Tests != 3 || suite.Failures != 1 || suite.Skipped != 1 {
+		t.Errorf("suite counts = tests=%d failures=%d skipped=%d, want 3/1/1", suite.Tests, suite.Failures, suite.Skipped)
+	}
+}
+
+func TestXUnit2ParserFallsBackToTestNameWithoutMethod(t *testing.T) {
+	input := `<assemblies>
+	<assembly name="a">
+		<collection name="c">
+			<test name="standalone test name" type="T" time="0" result="Pass" />
+		</collection>
+	</assembly>
+</assemblies>`
+
+	suites, err := XUnit2Parser{}.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if got := suites.TestSuites[0].TestCases[0].Name; got != "standalone test name" {
+		t.Errorf("Name = %q, want %q", got, "standalone test name")
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingOptions configures the OTLP/HTTP exporter used for the reporter's
+// span tree. A zero value disables tracing entirely.
+type TracingOptions struct {
+	OTLPEndpoint string
+	OTLPHeaders  map[string]string
+}
+
+var propagator = propagation.TraceContext{}
+
+// setupTracing wires an OTLP/HTTP exporter when Endpoint is set, and returns
+// a shutdown func to flush the exporter on exit. With no endpoint, tracing
+// is a no-op (the global noop TracerProvider already installed by otel).
+func setupTracing(ctx context.Context, opts TracingOptions) (shutdown func(context.Context) error, err error) {
+	if opts.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporterOpts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(opts.OTLPEndpoint)}
+	if len(opts.OTLPHeaders) > 0 {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithHeaders(opts.OTLPHeaders))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP exporter: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagator)
+
+	return tp.Shutdown, nil
+}
+
+// extractEnvTraceContext links the reporter's span tree into a trace already
+// started by the CI runner via the TRACEPARENT/TRACESTATE environment
+// variables, per the W3C Trace Context spec.
+func extractEnvTraceContext(ctx context.Context, getenv func(string) string) context.Context {
+	carrier := propagation.MapCarrier{}
+	if tp := getenv("TRACEPARENT"); tp != "" {
+		carrier.Set("traceparent", tp)
+	}
+	if ts := getenv("TRACESTATE"); ts != "" {
+		carrier.Set("tracestate", ts)
+	}
+	return propagator.Extract(ctx, carrier)
+}
+
+// parseBaggageJSON turns the flat string map decoded from --session-baggage
+// into a W3C Baggage so it can be forwarded via the `baggage` header in
+// addition to the JSON request body.
+func parseBaggageJSON(values map[string]any) (baggage.Baggage, error) {
+	var members []baggage.Member
+	for key, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			str = fmt.Sprintf("%v", value)
+		}
+		member, err := baggage.NewMember(key, str)
+		if err != nil {
+			return baggage.Baggage{}, fmt.Errorf("baggage member %q: %w", key, err)
+		}
+		members = append(members, member)
+	}
+	return baggage.New(members...)
+}
+
+// injectTraceHeaders sets traceparent/tracestate and baggage headers on an
+// outgoing request from the current span context.
+func injectTraceHeaders(ctx context.Context, req *http.Request) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if b := baggage.FromContext(ctx); b.Len() > 0 {
+		req.Header.Set("baggage", b.String())
+	}
+}
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := otel.Tracer("greener-reporter-junitxml")
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func parseOTLPHeaders(raw string) map[string]string {
+	if raw == "" {
+		return nil
+	}
+
+	headers := map[string]string{}
+	for pair := range strings.SplitSeq(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return headers
+}
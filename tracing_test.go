@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestExtractEnvTraceContext(t *testing.T) {
+	env := map[string]string{
+		"TRACEPARENT": testTraceparent,
+		"TRACESTATE":  "vendor=value",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	ctx := extractEnvTraceContext(context.Background(), getenv)
+
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		t.Fatal("extractEnvTraceContext() did not produce a valid span context")
+	}
+	if got, want := sc.TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+		t.Errorf("TraceID = %q, want %q", got, want)
+	}
+	if got, want := sc.SpanID().String(), "00f067aa0ba902b7"; got != want {
+		t.Errorf("SpanID = %q, want %q", got, want)
+	}
+	if got, want := sc.TraceState().String(), "vendor=value"; got != want {
+		t.Errorf("TraceState = %q, want %q", got, want)
+	}
+}
+
+func TestExtractEnvTraceContextNoEnv(t *testing.T) {
+	getenv := func(string) string { return "" }
+
+	ctx := extractEnvTraceContext(context.Background(), getenv)
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		t.Errorf("extractEnvTraceContext() with no env produced a valid span context: %v", sc)
+	}
+}
+
+func TestInjectTraceHeadersRoundTrip(t *testing.T) {
+	getenv := func(key string) string {
+		if key == "TRACEPARENT" {
+			return testTraceparent
+		}
+		return ""
+	}
+	ctx := extractEnvTraceContext(context.Background(), getenv)
+
+	req, err := http.NewRequest("POST", "https://ingress.example.com/", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error: %v", err)
+	}
+
+	injectTraceHeaders(ctx, req)
+
+	if got := req.Header.Get("traceparent"); got != testTraceparent {
+		t.Errorf("traceparent header = %q, want %q", got, testTraceparent)
+	}
+}
+
+func TestInjectTraceHeadersSetsBaggage(t *testing.T) {
+	member, err := baggage.NewMember("key", "value")
+	if err != nil {
+		t.Fatalf("NewMember() error: %v", err)
+	}
+	b, err := baggage.New(member)
+	if err != nil {
+		t.Fatalf("baggage.New() error: %v", err)
+	}
+	ctx := baggage.ContextWithBaggage(context.Background(), b)
+
+	req := httptest.NewRequest("POST", "https://ingress.example.com/", nil)
+	injectTraceHeaders(ctx, req)
+
+	if got := req.Header.Get("baggage"); got != "key=value" {
+		t.Errorf("baggage header = %q, want %q", got, "key=value")
+	}
+}
+
+func TestInjectTraceHeadersNoBaggage(t *testing.T) {
+	req := httptest.NewRequest("POST", "https://ingress.example.com/", nil)
+	injectTraceHeaders(context.Background(), req)
+
+	if got := req.Header.Get("baggage"); got != "" {
+		t.Errorf("baggage header = %q, want empty", got)
+	}
+}
+
+func TestParseBaggageJSON(t *testing.T) {
+	values := map[string]any{
+		"stringValue": "foo",
+		"numberValue": float64(42),
+	}
+
+	b, err := parseBaggageJSON(values)
+	if err != nil {
+		t.Fatalf("parseBaggageJSON() error: %v", err)
+	}
+
+	if got, want := b.Member("stringValue").Value(), "foo"; got != want {
+		t.Errorf("stringValue = %q, want %q", got, want)
+	}
+	if got, want := b.Member("numberValue").Value(), "42"; got != want {
+		t.Errorf("numberValue = %q, want %q", got, want)
+	}
+}
+
+func TestParseBaggageJSONEmpty(t *testing.T) {
+	b, err := parseBaggageJSON(nil)
+	if err != nil {
+		t.Fatalf("parseBaggageJSON() error: %v", err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", b.Len())
+	}
+}
+
+func TestParseOTLPHeaders(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want map[string]string
+	}{
+		{"empty", "", nil},
+		{"single", "x-api-key=secret", map[string]string{"x-api-key": "secret"}},
+		{"multiple with spaces", "a=1, b=2", map[string]string{"a": "1", "b": "2"}},
+		{"skips malformed pairs", "a=1,noequals,b=2", map[string]string{"a": "1", "b": "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseOTLPHeaders(tt.raw)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseOTLPHeaders(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}